@@ -0,0 +1,48 @@
+// Command api is the single Lambda that serves every laundry-os-api route,
+// dispatching on method + resource instead of deploying one function per
+// endpoint.
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"laundry-os-api/internal/api"
+	"laundry-os-api/internal/httpx"
+	"laundry-os-api/internal/store"
+)
+
+func handle(s *api.Server) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		status, body := s.Route(ctx, api.Request{
+			Method:  req.HTTPMethod,
+			Path:    req.Resource,
+			Headers: req.Headers,
+			Query:   req.QueryStringParameters,
+			Body:    []byte(req.Body),
+		})
+		return httpx.APIGatewayResponse(status, body)
+	}
+}
+
+func main() {
+	cfg, _ := config.LoadDefaultConfig(context.Background())
+
+	s := &api.Server{
+		Store: store.NewDynamoStore(dynamodb.NewFromConfig(cfg), store.Tables{
+			Commands: os.Getenv("COMMANDS_TABLE_NAME"),
+			Stats:    os.Getenv("STATS_TABLE_NAME"),
+			Devices:  os.Getenv("DEVICES_TABLE_NAME"),
+			Nonces:   os.Getenv("NONCES_TABLE_NAME"),
+			Runs:     os.Getenv("RUNS_TABLE_NAME"),
+		}),
+		AdminSecret: os.Getenv("ADMIN_SECRET"),
+	}
+
+	lambda.Start(handle(s))
+}
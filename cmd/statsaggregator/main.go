@@ -0,0 +1,62 @@
+// Command statsaggregator is the Lambda triggered by the runs table's
+// DynamoDB Stream. It folds each newly appended Run into the device's
+// aggregate stats via Store.IncrementStats, so GET /stats stays cheap to
+// read without anyone having to overwrite the aggregate row directly.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"laundry-os-api/internal/store"
+)
+
+// handler applies each INSERT record in event to the stats aggregate.
+// Streams deliver at-least-once, and with no ReportBatchItemFailures
+// configured on the event source mapping, returning an error here would
+// redeliver the *entire* batch, including records already folded into
+// IncrementStats earlier in this same invocation. So a single record's
+// failure is logged and skipped rather than propagated, the same as the
+// bad-amountCharged case below, to keep one bad or slow record from causing
+// the rest of the batch to be double-counted on retry.
+func handler(s store.Store) func(context.Context, events.DynamoDBEvent) error {
+	return func(ctx context.Context, event events.DynamoDBEvent) error {
+		for _, record := range event.Records {
+			if record.EventName != "INSERT" {
+				continue
+			}
+
+			deviceID := record.Change.NewImage["deviceId"].String()
+
+			amount, err := strconv.ParseFloat(record.Change.NewImage["amountCharged"].Number(), 64)
+			if err != nil {
+				log.Printf("statsaggregator: skipping record with bad amountCharged: %v", err)
+				continue
+			}
+
+			if err := s.IncrementStats(ctx, deviceID, amount, 1); err != nil {
+				log.Printf("statsaggregator: IncrementStats failed for device %s: %v", deviceID, err)
+				continue
+			}
+		}
+		return nil
+	}
+}
+
+func main() {
+	cfg, _ := config.LoadDefaultConfig(context.Background())
+
+	s := store.NewDynamoStore(dynamodb.NewFromConfig(cfg), store.Tables{
+		Stats: os.Getenv("STATS_TABLE_NAME"),
+		Runs:  os.Getenv("RUNS_TABLE_NAME"),
+	})
+
+	lambda.Start(handler(s))
+}
@@ -0,0 +1,469 @@
+// Package api implements laundry-os-api's request handlers against a
+// store.Store, independent of transport. cmd/api adapts them to API
+// Gateway's Lambda proxy integration; the root package adapts them to a
+// local net/http server.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"laundry-os-api/internal/auth"
+	"laundry-os-api/internal/model"
+	"laundry-os-api/internal/store"
+)
+
+// maxWait bounds how long LatestHandler will hold a request open for
+// long-polling, keeping us comfortably under API Gateway's 60s cap.
+// pollInterval is how often a store without Waiter support is re-checked.
+const (
+	maxWait      = 55 * time.Second
+	pollInterval = 1 * time.Second
+)
+
+// defaultRunsLimit and maxRunsLimit bound a single GET /runs page.
+const (
+	defaultRunsLimit = 50
+	maxRunsLimit     = 200
+)
+
+// Request is a transport-agnostic view of an incoming call.
+type Request struct {
+	Method  string
+	Path    string
+	Headers map[string]string
+	Query   map[string]string
+	Body    []byte
+}
+
+// HandlerFunc handles a Request and returns the status and JSON body to
+// send back.
+type HandlerFunc func(ctx context.Context, req Request) (status int, body any)
+
+// Server holds the dependencies every handler needs.
+type Server struct {
+	Store       store.Store
+	AdminSecret string
+}
+
+// Routes maps "METHOD path" to the handler that serves it.
+func (s *Server) Routes() map[string]HandlerFunc {
+	return map[string]HandlerFunc{
+		"POST /start":         s.StartHandler,
+		"GET /command/latest": s.LatestHandler,
+		"GET /stats":          s.GetStatsHandler,
+		"POST /stats":         s.UpdateStatsHandler,
+		"GET /runs":           s.RunsHandler,
+		"GET /stats/summary":  s.StatsSummaryHandler,
+		"POST /devices":       s.DevicesHandler,
+	}
+}
+
+// Route dispatches req to its handler, or 404s if nothing matches.
+func (s *Server) Route(ctx context.Context, req Request) (status int, body any) {
+	h, ok := s.Routes()[req.Method+" "+req.Path]
+	if !ok {
+		return 404, map[string]string{"error": "not found"}
+	}
+	return h(ctx, req)
+}
+
+// authenticate verifies the request's X-Device-Id/X-Timestamp/X-Nonce/
+// X-Signature headers against the device's stored secret and checks the
+// nonce for replay. deviceID is returned even on failure so callers can log
+// who was rejected.
+func (s *Server) authenticate(ctx context.Context, req Request) (deviceID string, ok bool, err error) {
+	deviceID = auth.HeaderLookup(req.Headers, "X-Device-Id")
+	if deviceID == "" {
+		return "", false, nil
+	}
+
+	secret, found, err := s.Store.DeviceSecret(ctx, deviceID)
+	if err != nil {
+		return deviceID, false, err
+	}
+	if !found {
+		return deviceID, false, nil
+	}
+
+	sigReq := auth.Request{
+		Method:    req.Method,
+		Path:      req.Path,
+		Timestamp: auth.HeaderLookup(req.Headers, "X-Timestamp"),
+		Nonce:     auth.HeaderLookup(req.Headers, "X-Nonce"),
+		Body:      req.Body,
+	}
+
+	if err := auth.Verify(sigReq, secret, auth.HeaderLookup(req.Headers, "X-Signature")); err != nil {
+		return deviceID, false, nil
+	}
+
+	fresh, err := s.Store.CheckAndStoreNonce(ctx, sigReq.Nonce)
+	if err != nil {
+		return deviceID, false, err
+	}
+	return deviceID, fresh, nil
+}
+
+// StartHandler handles POST /start: queue a new command for a device.
+func (s *Server) StartHandler(ctx context.Context, req Request) (int, any) {
+	deviceID, ok, err := s.authenticate(ctx, req)
+	if err != nil {
+		return 500, map[string]string{"error": "auth check failed"}
+	}
+	if !ok {
+		return 401, map[string]string{"error": "unauthorized"}
+	}
+
+	var cmd model.Command
+	if err := json.Unmarshal(req.Body, &cmd); err != nil {
+		return 400, map[string]string{"error": "invalid json"}
+	}
+	cmd.DeviceID = deviceID // headers are authoritative, not the body
+
+	if cmd.Type != "wash" && cmd.Type != "dry" {
+		return 400, map[string]string{"error": "type must be wash or dry"}
+	}
+	if cmd.DurationMinutes <= 0 {
+		return 400, map[string]string{"error": "durationMinutes must be > 0"}
+	}
+
+	cmd.CreatedAt = time.Now().UnixMilli()
+
+	if err := s.Store.PutCommand(ctx, cmd); err != nil {
+		return 500, map[string]string{"error": err.Error()}
+	}
+
+	return 200, map[string]any{"ok": true, "createdAt": cmd.CreatedAt}
+}
+
+// LatestResponse is the body returned by LatestHandler.
+type LatestResponse struct {
+	Command *model.Command `json:"command"`
+}
+
+// LatestHandler handles GET /command/latest. When the request carries a
+// "wait" query param it long-polls: if s.Store implements store.Waiter it
+// waits on a wake channel, otherwise it falls back to short-interval
+// polling (the only option across separate Lambda invocations).
+func (s *Server) LatestHandler(ctx context.Context, req Request) (int, any) {
+	deviceID, ok, err := s.authenticate(ctx, req)
+	if err != nil {
+		return 500, map[string]string{"error": "auth check failed"}
+	}
+	if !ok {
+		return 401, map[string]string{"error": "unauthorized"}
+	}
+
+	wait := parseWait(req.Query["wait"])
+	deadline := time.Now().Add(wait)
+
+	waiter, canWait := s.Store.(store.Waiter)
+
+	for {
+		if canWait {
+			cmd, found, wakeCh, err := waiter.WaitForCommand(ctx, deviceID)
+			if err != nil {
+				return 500, map[string]string{"error": "get failed"}
+			}
+			if found {
+				return 200, LatestResponse{Command: cmd}
+			}
+
+			remaining := time.Until(deadline)
+			if wait <= 0 || remaining <= 0 {
+				return 200, LatestResponse{Command: nil}
+			}
+
+			deadlineCh := make(chan struct{})
+			timer := time.AfterFunc(remaining, func() { close(deadlineCh) })
+
+			select {
+			case <-wakeCh:
+				timer.Stop()
+				// a new command may have just landed; loop around and recheck
+			case <-deadlineCh:
+				return 200, LatestResponse{Command: nil}
+			case <-ctx.Done():
+				timer.Stop()
+				return 200, LatestResponse{Command: nil}
+			}
+			continue
+		}
+
+		cmd, found, err := s.Store.TakeUnservedCommand(ctx, deviceID)
+		if err != nil {
+			return 500, map[string]string{"error": "get failed"}
+		}
+		if found {
+			return 200, LatestResponse{Command: cmd}
+		}
+
+		remaining := time.Until(deadline)
+		if wait <= 0 || remaining <= 0 {
+			return 200, LatestResponse{Command: nil}
+		}
+
+		sleep := pollInterval
+		if remaining < sleep {
+			sleep = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return 200, LatestResponse{Command: nil}
+		case <-time.After(sleep):
+		}
+	}
+}
+
+// parseWait reads the "wait" query param (e.g. "30s") and clamps it to
+// [0, maxWait]. An empty or invalid value means no long-polling.
+func parseWait(raw string) time.Duration {
+	if raw == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0
+	}
+	if d > maxWait {
+		return maxWait
+	}
+	return d
+}
+
+// GetStatsHandler handles GET /stats.
+func (s *Server) GetStatsHandler(ctx context.Context, req Request) (int, any) {
+	deviceID, ok, err := s.authenticate(ctx, req)
+	if err != nil {
+		return 500, map[string]string{"error": "auth check failed"}
+	}
+	if !ok {
+		return 401, map[string]string{"error": "unauthorized"}
+	}
+
+	stats, found, err := s.Store.GetStats(ctx, deviceID)
+	if err != nil {
+		return 500, map[string]string{"error": "get failed"}
+	}
+	if !found {
+		return 404, map[string]string{"error": "not found"}
+	}
+
+	return 200, stats
+}
+
+// runRequest is the body of POST /stats: one completed wash/dry cycle,
+// appended as a Run rather than overwriting the device's aggregates.
+type runRequest struct {
+	Type            string  `json:"type"`
+	DurationMinutes int     `json:"durationMinutes"`
+	AmountCharged   float64 `json:"amountCharged"`
+	StartedAt       int64   `json:"startedAt"`
+	EndedAt         int64   `json:"endedAt"`
+	ClientRequestID string  `json:"clientRequestId"`
+}
+
+// UpdateStatsHandler handles POST /stats: append a completed cycle to the
+// device's run history. Aggregates are folded in via Store.PutRun (inline
+// for MemStore; via the runs-stream aggregator for DynamoStore) rather than
+// overwritten here, so a retried request can't double-count.
+func (s *Server) UpdateStatsHandler(ctx context.Context, req Request) (int, any) {
+	deviceID, ok, err := s.authenticate(ctx, req)
+	if err != nil {
+		return 500, map[string]string{"error": "auth check failed"}
+	}
+	if !ok {
+		return 401, map[string]string{"error": "unauthorized"}
+	}
+
+	var body runRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return 400, map[string]string{"error": "invalid json"}
+	}
+	if body.Type != "wash" && body.Type != "dry" {
+		return 400, map[string]string{"error": "type must be wash or dry"}
+	}
+	if body.DurationMinutes <= 0 {
+		return 400, map[string]string{"error": "durationMinutes must be > 0"}
+	}
+	if body.StartedAt <= 0 || body.EndedAt <= 0 {
+		return 400, map[string]string{"error": "startedAt and endedAt required"}
+	}
+	if body.EndedAt < body.StartedAt {
+		return 400, map[string]string{"error": "endedAt must be >= startedAt"}
+	}
+	if body.AmountCharged < 0 {
+		return 400, map[string]string{"error": "amountCharged must be >= 0"}
+	}
+	if body.ClientRequestID == "" {
+		return 400, map[string]string{"error": "clientRequestId required"}
+	}
+
+	run := model.Run{
+		DeviceID:        deviceID, // headers are authoritative, not the body
+		CreatedAt:       body.StartedAt,
+		Type:            body.Type,
+		DurationMinutes: body.DurationMinutes,
+		AmountCharged:   body.AmountCharged,
+		StartedAt:       body.StartedAt,
+		EndedAt:         body.EndedAt,
+		ClientRequestID: body.ClientRequestID,
+	}
+
+	accepted, err := s.Store.PutRun(ctx, run)
+	if err != nil {
+		return 500, map[string]string{"error": err.Error()}
+	}
+
+	return 200, map[string]any{"ok": true, "accepted": accepted}
+}
+
+// runsResponse is the body returned by RunsHandler.
+type runsResponse struct {
+	Runs       []model.Run `json:"runs"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// RunsHandler handles GET /runs?deviceId=...&since=...&limit=...&cursor=...,
+// so operators can page through a device's raw run history to reconcile
+// revenue. Gated by X-Admin-Secret like DevicesHandler, since callers here
+// are operators rather than devices.
+func (s *Server) RunsHandler(ctx context.Context, req Request) (int, any) {
+	if !s.isAdmin(req) {
+		return 401, map[string]string{"error": "unauthorized"}
+	}
+
+	deviceID := req.Query["deviceId"]
+	if deviceID == "" {
+		return 400, map[string]string{"error": "deviceId required"}
+	}
+
+	since := parseInt64(req.Query["since"], 0)
+	limit := parseLimit(req.Query["limit"], defaultRunsLimit, maxRunsLimit)
+
+	runs, nextCursor, err := s.Store.ListRuns(ctx, deviceID, since, limit, req.Query["cursor"])
+	if err != nil {
+		return 500, map[string]string{"error": "list failed"}
+	}
+
+	return 200, runsResponse{Runs: runs, NextCursor: nextCursor}
+}
+
+// StatsSummaryHandler handles GET /stats/summary?deviceId=...&from=...&to=...,
+// summing run history over an arbitrary range instead of the all-time
+// aggregate GetStatsHandler returns.
+func (s *Server) StatsSummaryHandler(ctx context.Context, req Request) (int, any) {
+	if !s.isAdmin(req) {
+		return 401, map[string]string{"error": "unauthorized"}
+	}
+
+	deviceID := req.Query["deviceId"]
+	if deviceID == "" {
+		return 400, map[string]string{"error": "deviceId required"}
+	}
+
+	from := parseInt64(req.Query["from"], 0)
+	to := parseInt64(req.Query["to"], time.Now().UnixMilli())
+
+	stats, err := s.Store.StatsSummary(ctx, deviceID, from, to)
+	if err != nil {
+		return 500, map[string]string{"error": "summary failed"}
+	}
+
+	return 200, stats
+}
+
+// parseInt64 parses raw as a base-10 int64, returning def if raw is empty or
+// invalid.
+func parseInt64(raw string, def int64) int64 {
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// parseLimit parses raw as a page size, clamped to (0, max], falling back to
+// def if raw is empty or invalid.
+func parseLimit(raw string, def, max int) int {
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return def
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// devicesRequest is the body of POST /devices.
+type devicesRequest struct {
+	DeviceID string `json:"deviceId"`
+}
+
+// devicesResponse is the body returned by DevicesHandler.
+type devicesResponse struct {
+	DeviceID string `json:"deviceId"`
+	Secret   string `json:"secret"`
+}
+
+// DevicesHandler handles POST /devices: provision or rotate a device's HMAC
+// secret. Gated by X-Admin-Secret so only operators can mint credentials.
+func (s *Server) DevicesHandler(ctx context.Context, req Request) (int, any) {
+	if !s.isAdmin(req) {
+		return 401, map[string]string{"error": "unauthorized"}
+	}
+
+	var body devicesRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return 400, map[string]string{"error": "invalid json"}
+	}
+
+	deviceID := strings.TrimSpace(body.DeviceID)
+	if deviceID == "" {
+		return 400, map[string]string{"error": "deviceId required"}
+	}
+
+	secret, err := generateDeviceSecret()
+	if err != nil {
+		return 500, map[string]string{"error": "failed to generate secret"}
+	}
+
+	if err := s.Store.PutDeviceSecret(ctx, deviceID, secret); err != nil {
+		return 500, map[string]string{"error": "put failed"}
+	}
+
+	return 200, devicesResponse{DeviceID: deviceID, Secret: secret}
+}
+
+// isAdmin reports whether req carries the operator credential configured on
+// s.AdminSecret. Used to gate the operator-facing endpoints (device
+// provisioning, run history, stats summaries) that aren't device-signed.
+func (s *Server) isAdmin(req Request) bool {
+	return s.AdminSecret != "" && timingSafeEq(auth.HeaderLookup(req.Headers, "X-Admin-Secret"), s.AdminSecret)
+}
+
+func generateDeviceSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func timingSafeEq(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"laundry-os-api/internal/auth"
+	"laundry-os-api/internal/model"
+	"laundry-os-api/internal/store"
+)
+
+func newTestServer() (*Server, *store.MemStore) {
+	mem := store.NewMemStore()
+	return &Server{Store: mem, AdminSecret: "admin-secret"}, mem
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return b
+}
+
+// signedRequest builds a Request carrying a valid (or, for negative cases,
+// deliberately mismatched) X-Signature for deviceID.
+func signedRequest(deviceID, secret, method, path, nonce string, body []byte) Request {
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return Request{
+		Method: method,
+		Path:   path,
+		Body:   body,
+		Headers: map[string]string{
+			"X-Device-Id": deviceID,
+			"X-Timestamp": timestamp,
+			"X-Nonce":     nonce,
+			"X-Signature": auth.Sign(secret, method, path, timestamp, nonce, body),
+		},
+	}
+}
+
+func TestStartHandler(t *testing.T) {
+	const secret = "device-secret"
+	validBody := mustJSON(t, model.Command{Type: "wash", DurationMinutes: 30})
+
+	cases := []struct {
+		name       string
+		deviceID   string
+		secret     string
+		body       []byte
+		wantStatus int
+	}{
+		{"valid command", "dev-1", secret, validBody, 200},
+		{"unknown device", "dev-2", secret, validBody, 401},
+		{"wrong secret", "dev-1", "not-the-secret", validBody, 401},
+		{"bad type", "dev-1", secret, mustJSON(t, model.Command{Type: "spin", DurationMinutes: 30}), 400},
+		{"zero duration", "dev-1", secret, mustJSON(t, model.Command{Type: "wash", DurationMinutes: 0}), 400},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mem := newTestServer()
+			if err := mem.PutDeviceSecret(context.Background(), "dev-1", secret); err != nil {
+				t.Fatalf("PutDeviceSecret: %v", err)
+			}
+
+			req := signedRequest(tc.deviceID, tc.secret, "POST", "/start", strconv.Itoa(i), tc.body)
+			status, _ := s.StartHandler(context.Background(), req)
+			if status != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestLatestHandler(t *testing.T) {
+	const secret = "device-secret"
+	ctx := context.Background()
+
+	s, mem := newTestServer()
+	if err := mem.PutDeviceSecret(ctx, "dev-1", secret); err != nil {
+		t.Fatalf("PutDeviceSecret: %v", err)
+	}
+
+	status, body := s.LatestHandler(ctx, signedRequest("dev-1", secret, "GET", "/command/latest", "n1", nil))
+	if status != 200 {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if got := body.(LatestResponse).Command; got != nil {
+		t.Fatalf("command = %+v, want nil before any start", got)
+	}
+
+	startBody := mustJSON(t, model.Command{Type: "wash", DurationMinutes: 10})
+	if status, _ := s.StartHandler(ctx, signedRequest("dev-1", secret, "POST", "/start", "n2", startBody)); status != 200 {
+		t.Fatalf("start status = %d, want 200", status)
+	}
+
+	status, body = s.LatestHandler(ctx, signedRequest("dev-1", secret, "GET", "/command/latest", "n3", nil))
+	cmd := body.(LatestResponse).Command
+	if status != 200 || cmd == nil || cmd.Type != "wash" {
+		t.Fatalf("status = %d, command = %+v, want wash command", status, cmd)
+	}
+
+	status, body = s.LatestHandler(ctx, signedRequest("dev-1", secret, "GET", "/command/latest", "n4", nil))
+	if got := body.(LatestResponse).Command; got != nil {
+		t.Fatalf("command = %+v, want nil on second fetch (serve-once)", got)
+	}
+
+	// Replaying a nonce must be rejected even though the signature is valid.
+	status, _ = s.LatestHandler(ctx, signedRequest("dev-1", secret, "GET", "/command/latest", "n3", nil))
+	if status != 401 {
+		t.Fatalf("status = %d, want 401 for replayed nonce", status)
+	}
+}
+
+func TestStatsHandlers(t *testing.T) {
+	const secret = "device-secret"
+	ctx := context.Background()
+
+	s, mem := newTestServer()
+	if err := mem.PutDeviceSecret(ctx, "dev-1", secret); err != nil {
+		t.Fatalf("PutDeviceSecret: %v", err)
+	}
+
+	status, _ := s.GetStatsHandler(ctx, signedRequest("dev-1", secret, "GET", "/stats", "n1", nil))
+	if status != 404 {
+		t.Fatalf("status = %d, want 404 before any run", status)
+	}
+
+	invalidCases := []struct {
+		name string
+		run  runRequest
+	}{
+		{"bad type", runRequest{Type: "spin", DurationMinutes: 30, StartedAt: 1000, EndedAt: 2000, ClientRequestID: "req-bad-type"}},
+		{"zero duration", runRequest{Type: "wash", DurationMinutes: 0, StartedAt: 1000, EndedAt: 2000, ClientRequestID: "req-bad-duration"}},
+		{"ended before started", runRequest{Type: "wash", DurationMinutes: 30, StartedAt: 2000, EndedAt: 1000, ClientRequestID: "req-bad-range"}},
+		{"negative amount", runRequest{Type: "wash", DurationMinutes: 30, AmountCharged: -1, StartedAt: 1000, EndedAt: 2000, ClientRequestID: "req-bad-amount"}},
+	}
+	for i, tc := range invalidCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, _ := s.UpdateStatsHandler(ctx, signedRequest("dev-1", secret, "POST", "/stats", "bad-"+strconv.Itoa(i), mustJSON(t, tc.run)))
+			if status != 400 {
+				t.Fatalf("status = %d, want 400", status)
+			}
+		})
+	}
+
+	run1 := runRequest{Type: "wash", DurationMinutes: 30, AmountCharged: 4.5, StartedAt: 1000, EndedAt: 2000, ClientRequestID: "req-1"}
+	status, body := s.UpdateStatsHandler(ctx, signedRequest("dev-1", secret, "POST", "/stats", "n2", mustJSON(t, run1)))
+	if status != 200 || !body.(map[string]any)["accepted"].(bool) {
+		t.Fatalf("update status = %d, body = %+v, want 200 accepted", status, body)
+	}
+
+	run2 := runRequest{Type: "dry", DurationMinutes: 45, AmountCharged: 6, StartedAt: 3000, EndedAt: 4000, ClientRequestID: "req-2"}
+	if status, _ := s.UpdateStatsHandler(ctx, signedRequest("dev-1", secret, "POST", "/stats", "n3", mustJSON(t, run2))); status != 200 {
+		t.Fatalf("second update status = %d, want 200", status)
+	}
+
+	// Retrying the first run (same clientRequestId) must not double-count.
+	status, body = s.UpdateStatsHandler(ctx, signedRequest("dev-1", secret, "POST", "/stats", "n4", mustJSON(t, run1)))
+	if status != 200 || body.(map[string]any)["accepted"].(bool) {
+		t.Fatalf("retried update status = %d, body = %+v, want 200 not-accepted", status, body)
+	}
+
+	status, body = s.GetStatsHandler(ctx, signedRequest("dev-1", secret, "GET", "/stats", "n5", nil))
+	stats := body.(*model.Stats)
+	if status != 200 || stats.NumRuns != 2 || stats.TotalSpent != 10.5 {
+		t.Fatalf("status = %d, stats = %+v, want {10.5 2}", status, stats)
+	}
+}
+
+func TestRunsHandler(t *testing.T) {
+	const secret = "device-secret"
+	ctx := context.Background()
+
+	s, mem := newTestServer()
+	if err := mem.PutDeviceSecret(ctx, "dev-1", secret); err != nil {
+		t.Fatalf("PutDeviceSecret: %v", err)
+	}
+
+	for i, r := range []runRequest{
+		{Type: "wash", DurationMinutes: 30, AmountCharged: 4.5, StartedAt: 1000, EndedAt: 2000, ClientRequestID: "req-1"},
+		{Type: "dry", DurationMinutes: 45, AmountCharged: 6, StartedAt: 3000, EndedAt: 4000, ClientRequestID: "req-2"},
+	} {
+		if status, _ := s.UpdateStatsHandler(ctx, signedRequest("dev-1", secret, "POST", "/stats", strconv.Itoa(i), mustJSON(t, r))); status != 200 {
+			t.Fatalf("update status = %d, want 200", status)
+		}
+	}
+
+	cases := []struct {
+		name        string
+		adminHeader string
+		query       map[string]string
+		wantStatus  int
+		wantRuns    int
+	}{
+		{"missing admin secret", "", map[string]string{"deviceId": "dev-1"}, 401, 0},
+		{"missing deviceId", s.AdminSecret, map[string]string{}, 400, 0},
+		{"all runs", s.AdminSecret, map[string]string{"deviceId": "dev-1"}, 200, 2},
+		{"since filters older run", s.AdminSecret, map[string]string{"deviceId": "dev-1", "since": "2000"}, 200, 1},
+		{"limit caps page", s.AdminSecret, map[string]string{"deviceId": "dev-1", "limit": "1"}, 200, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, body := s.RunsHandler(ctx, Request{
+				Method:  "GET",
+				Path:    "/runs",
+				Headers: map[string]string{"X-Admin-Secret": tc.adminHeader},
+				Query:   tc.query,
+			})
+			if status != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tc.wantStatus)
+			}
+			if status == 200 {
+				if got := len(body.(runsResponse).Runs); got != tc.wantRuns {
+					t.Fatalf("len(runs) = %d, want %d", got, tc.wantRuns)
+				}
+			}
+		})
+	}
+}
+
+func TestStatsSummaryHandler(t *testing.T) {
+	const secret = "device-secret"
+	ctx := context.Background()
+
+	s, mem := newTestServer()
+	if err := mem.PutDeviceSecret(ctx, "dev-1", secret); err != nil {
+		t.Fatalf("PutDeviceSecret: %v", err)
+	}
+
+	for i, r := range []runRequest{
+		{Type: "wash", DurationMinutes: 30, AmountCharged: 4.5, StartedAt: 1000, EndedAt: 2000, ClientRequestID: "req-1"},
+		{Type: "dry", DurationMinutes: 45, AmountCharged: 6, StartedAt: 5000, EndedAt: 6000, ClientRequestID: "req-2"},
+	} {
+		if status, _ := s.UpdateStatsHandler(ctx, signedRequest("dev-1", secret, "POST", "/stats", strconv.Itoa(i), mustJSON(t, r))); status != 200 {
+			t.Fatalf("update status = %d, want 200", status)
+		}
+	}
+
+	status, body := s.StatsSummaryHandler(ctx, Request{
+		Method:  "GET",
+		Path:    "/stats/summary",
+		Headers: map[string]string{"X-Admin-Secret": s.AdminSecret},
+		Query:   map[string]string{"deviceId": "dev-1", "from": "0", "to": "4000"},
+	})
+	stats := body.(*model.Stats)
+	if status != 200 || stats.NumRuns != 1 || stats.TotalSpent != 4.5 {
+		t.Fatalf("status = %d, stats = %+v, want {4.5 1} for the first run only", status, stats)
+	}
+}
+
+func TestDevicesHandler(t *testing.T) {
+	s, _ := newTestServer()
+	ctx := context.Background()
+
+	cases := []struct {
+		name        string
+		adminHeader string
+		body        []byte
+		wantStatus  int
+	}{
+		{"missing admin secret", "", []byte(`{"deviceId":"dev-1"}`), 401},
+		{"wrong admin secret", "wrong", []byte(`{"deviceId":"dev-1"}`), 401},
+		{"missing deviceId", s.AdminSecret, []byte(`{}`), 400},
+		{"valid", s.AdminSecret, []byte(`{"deviceId":"dev-1"}`), 200},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := Request{
+				Method:  "POST",
+				Path:    "/devices",
+				Headers: map[string]string{"X-Admin-Secret": tc.adminHeader},
+				Body:    tc.body,
+			}
+			status, _ := s.DevicesHandler(ctx, req)
+			if status != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRouteNotFound(t *testing.T) {
+	s, _ := newTestServer()
+	status, _ := s.Route(context.Background(), Request{Method: "GET", Path: "/nope"})
+	if status != 404 {
+		t.Fatalf("status = %d, want 404", status)
+	}
+}
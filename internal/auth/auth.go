@@ -0,0 +1,122 @@
+// Package auth implements the per-device HMAC request signing scheme shared
+// by every laundry-os-api handler, Lambda and local alike. It verifies
+// signatures and clock skew; nonce replay storage is left to the caller
+// since that differs between the in-memory local server and DynamoDB-backed
+// Lambdas.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// MaxClockSkew is how far X-Timestamp may drift from the server's clock
+// before a request is rejected.
+const MaxClockSkew = 5 * time.Minute
+
+var (
+	ErrMissingHeaders = errors.New("auth: missing device id, timestamp, nonce, or signature")
+	ErrBadTimestamp   = errors.New("auth: timestamp is not a valid unix millis value")
+	ErrClockSkew      = errors.New("auth: timestamp outside allowed window")
+	ErrBadSignature   = errors.New("auth: signature mismatch")
+)
+
+// Request carries the pieces of an incoming call needed to verify its
+// X-Signature header.
+type Request struct {
+	Method    string
+	Path      string
+	Timestamp string // X-Timestamp: unix millis, as received
+	Nonce     string // X-Nonce: random bytes, base64, as received
+	Body      []byte
+}
+
+// Verify checks req's HMAC-SHA256 signature against deviceSecret and that
+// its timestamp falls within MaxClockSkew of now. It does not check for
+// replay; call Store.CheckAndStoreNonce (e.g. a conditional DynamoDB put)
+// afterwards.
+func Verify(req Request, deviceSecret, signature string) error {
+	if req.Timestamp == "" || req.Nonce == "" || signature == "" || deviceSecret == "" {
+		return ErrMissingHeaders
+	}
+
+	millis, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return ErrBadTimestamp
+	}
+
+	skew := time.Since(time.UnixMilli(millis))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > MaxClockSkew {
+		return ErrClockSkew
+	}
+
+	expected := Sign(deviceSecret, req.Method, req.Path, req.Timestamp, req.Nonce, req.Body)
+	if !timingSafeEq(signature, expected) {
+		return ErrBadSignature
+	}
+
+	return nil
+}
+
+// Sign computes base64(HMAC-SHA256(deviceSecret, method + "\n" + path + "\n"
+// + timestamp + "\n" + nonce + "\n" + hex(sha256(body)))).
+func Sign(deviceSecret, method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(deviceSecret))
+	mac.Write([]byte(method))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(path))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(hex.EncodeToString(bodyHash[:])))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func timingSafeEq(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// HeaderLookup does a case-insensitive lookup in a plain header map, for
+// API Gateway's events.APIGatewayProxyRequest.Headers which isn't backed by
+// http.Header's canonicalization.
+func HeaderLookup(headers map[string]string, key string) string {
+	if v, ok := headers[key]; ok {
+		return v
+	}
+	for k, v := range headers {
+		if len(k) == len(key) && equalFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func equalFold(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+// Package httpx renders handler results for each transport laundry-os-api
+// runs on: API Gateway's Lambda proxy integration, and the local net/http
+// dev server.
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// corsHeaders are applied to every response; wide open for v1.
+var corsHeaders = map[string]string{
+	"Content-Type":                "application/json",
+	"Access-Control-Allow-Origin": "*",
+}
+
+// APIGatewayResponse renders (status, body) as an API Gateway proxy
+// response.
+func APIGatewayResponse(status int, body any) (events.APIGatewayProxyResponse, error) {
+	b, _ := json.Marshal(body)
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    corsHeaders,
+		Body:       string(b),
+	}, nil
+}
+
+// WriteJSON renders (status, body) onto a local net/http response.
+func WriteJSON(w http.ResponseWriter, status int, body any) {
+	for k, v := range corsHeaders {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
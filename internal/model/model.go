@@ -0,0 +1,34 @@
+// Package model holds the domain types shared across every laundry-os-api
+// handler and store implementation.
+package model
+
+// Command is a single instruction sent to a machine's ESP32, e.g. "start a
+// 45 minute wash."
+type Command struct {
+	DeviceID        string `json:"deviceId"`
+	Type            string `json:"type"` // wash | dry
+	DurationMinutes int    `json:"durationMinutes"`
+	CreatedAt       int64  `json:"createdAt"`
+}
+
+// Stats is a device's running totals, maintained as an aggregate over its
+// Run history rather than written directly.
+type Stats struct {
+	DeviceID   string  `json:"deviceId" dynamodbav:"deviceId"`
+	TotalSpent float64 `json:"totalSpent" dynamodbav:"totalSpent"`
+	NumRuns    int64   `json:"numRuns" dynamodbav:"numRuns"`
+}
+
+// Run is one immutable, completed wash/dry cycle. It is appended, never
+// overwritten; deviceId + createdAt is its DynamoDB key, and clientRequestId
+// exists so a client retry of the same cycle doesn't get double-counted.
+type Run struct {
+	DeviceID        string  `json:"deviceId" dynamodbav:"deviceId"`
+	CreatedAt       int64   `json:"createdAt" dynamodbav:"createdAt"`
+	Type            string  `json:"type" dynamodbav:"type"`
+	DurationMinutes int     `json:"durationMinutes" dynamodbav:"durationMinutes"`
+	AmountCharged   float64 `json:"amountCharged" dynamodbav:"amountCharged"`
+	StartedAt       int64   `json:"startedAt" dynamodbav:"startedAt"`
+	EndedAt         int64   `json:"endedAt" dynamodbav:"endedAt"`
+	ClientRequestID string  `json:"clientRequestId" dynamodbav:"clientRequestId"`
+}
@@ -0,0 +1,357 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"laundry-os-api/internal/model"
+)
+
+// Tables names the DynamoDB tables a DynamoStore reads and writes.
+type Tables struct {
+	Commands string
+	Stats    string
+	Devices  string
+	Nonces   string
+	Runs     string
+}
+
+// DynamoStore is the Store used by the deployed Lambda.
+type DynamoStore struct {
+	ddb    *dynamodb.Client
+	tables Tables
+}
+
+// NewDynamoStore returns a Store backed by the given DynamoDB client and
+// table names.
+func NewDynamoStore(ddb *dynamodb.Client, tables Tables) *DynamoStore {
+	return &DynamoStore{ddb: ddb, tables: tables}
+}
+
+func (d *DynamoStore) PutCommand(ctx context.Context, cmd model.Command) error {
+	_, err := d.ddb.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.tables.Commands),
+		Item: map[string]types.AttributeValue{
+			"deviceId":        &types.AttributeValueMemberS{Value: cmd.DeviceID},
+			"type":            &types.AttributeValueMemberS{Value: cmd.Type},
+			"durationMinutes": &types.AttributeValueMemberN{Value: strconv.Itoa(cmd.DurationMinutes)},
+			"createdAt":       &types.AttributeValueMemberN{Value: strconv.FormatInt(cmd.CreatedAt, 10)},
+			// servedAt intentionally NOT set (means "not served yet")
+		},
+	})
+	return err
+}
+
+func (d *DynamoStore) TakeUnservedCommand(ctx context.Context, deviceID string) (*model.Command, bool, error) {
+	out, err := d.ddb.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.tables.Commands),
+		Key: map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: deviceID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if out.Item == nil {
+		return nil, false, nil
+	}
+
+	// if servedAt exists, return null (serve-once)
+	if _, ok := out.Item["servedAt"]; ok {
+		return nil, false, nil
+	}
+
+	cmd := &model.Command{DeviceID: deviceID}
+
+	if v, ok := out.Item["type"].(*types.AttributeValueMemberS); ok {
+		cmd.Type = v.Value
+	}
+	if v, ok := out.Item["durationMinutes"].(*types.AttributeValueMemberN); ok {
+		cmd.DurationMinutes = int(mustInt64(v.Value))
+	}
+	if v, ok := out.Item["createdAt"].(*types.AttributeValueMemberN); ok {
+		cmd.CreatedAt = mustInt64(v.Value)
+	}
+
+	// mark served; the condition makes this the atomic step, so only one
+	// caller can take this command, and a newer command put since the read
+	// is left unserved
+	now := time.Now().UnixMilli()
+	_, err = d.ddb.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.tables.Commands),
+		Key: map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: deviceID},
+		},
+		UpdateExpression:    aws.String("SET servedAt = :now"),
+		ConditionExpression: aws.String("attribute_not_exists(servedAt) AND createdAt = :createdAt"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now":       &types.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+			":createdAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(cmd.CreatedAt, 10)},
+		},
+	})
+	if err != nil {
+		var condFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &condFailed) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return cmd, true, nil
+}
+
+func (d *DynamoStore) GetStats(ctx context.Context, deviceID string) (*model.Stats, bool, error) {
+	out, err := d.ddb.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.tables.Stats),
+		Key: map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: deviceID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if out.Item == nil {
+		return nil, false, nil
+	}
+
+	stats := &model.Stats{DeviceID: deviceID}
+
+	if v, ok := out.Item["totalSpent"].(*types.AttributeValueMemberN); ok {
+		total, err := strconv.ParseFloat(v.Value, 64)
+		if err != nil {
+			return nil, false, err
+		}
+		stats.TotalSpent = total
+	}
+
+	if v, ok := out.Item["numRuns"].(*types.AttributeValueMemberN); ok {
+		total, err := strconv.ParseInt(v.Value, 10, 64)
+		if err != nil {
+			return nil, false, err
+		}
+		stats.NumRuns = total
+	}
+
+	return stats, true, nil
+}
+
+// IncrementStats folds amount/numRuns into the device's aggregate row via a
+// DynamoDB ADD, so concurrent runs for the same device don't race each other
+// the way a blind PutItem would. Called by the runs-stream aggregator.
+func (d *DynamoStore) IncrementStats(ctx context.Context, deviceID string, amount float64, numRuns int64) error {
+	_, err := d.ddb.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.tables.Stats),
+		Key: map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: deviceID},
+		},
+		UpdateExpression: aws.String("ADD totalSpent :amt, numRuns :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":amt": &types.AttributeValueMemberN{Value: strconv.FormatFloat(amount, 'f', 2, 64)},
+			":one": &types.AttributeValueMemberN{Value: strconv.FormatInt(numRuns, 10)},
+		},
+	})
+	return err
+}
+
+// PutRun appends an immutable run record. The condition on clientRequestId
+// means a retry that reuses the same (deviceId, createdAt, clientRequestId)
+// is a no-op rather than a duplicate charge.
+func (d *DynamoStore) PutRun(ctx context.Context, run model.Run) (bool, error) {
+	_, err := d.ddb.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.tables.Runs),
+		Item: map[string]types.AttributeValue{
+			"deviceId":        &types.AttributeValueMemberS{Value: run.DeviceID},
+			"createdAt":       &types.AttributeValueMemberN{Value: strconv.FormatInt(run.CreatedAt, 10)},
+			"type":            &types.AttributeValueMemberS{Value: run.Type},
+			"durationMinutes": &types.AttributeValueMemberN{Value: strconv.Itoa(run.DurationMinutes)},
+			"amountCharged":   &types.AttributeValueMemberN{Value: strconv.FormatFloat(run.AmountCharged, 'f', 2, 64)},
+			"startedAt":       &types.AttributeValueMemberN{Value: strconv.FormatInt(run.StartedAt, 10)},
+			"endedAt":         &types.AttributeValueMemberN{Value: strconv.FormatInt(run.EndedAt, 10)},
+			"clientRequestId": &types.AttributeValueMemberS{Value: run.ClientRequestID},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(clientRequestId)"),
+	})
+	if err != nil {
+		var condFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &condFailed) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ListRuns queries the runs table for deviceId, paging via LastEvaluatedKey.
+// cursor, when set, is the createdAt of the last item from a previous page.
+func (d *DynamoStore) ListRuns(ctx context.Context, deviceID string, since int64, limit int, cursor string) ([]model.Run, string, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(d.tables.Runs),
+		KeyConditionExpression: aws.String("deviceId = :d AND createdAt >= :since"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":d":     &types.AttributeValueMemberS{Value: deviceID},
+			":since": &types.AttributeValueMemberN{Value: strconv.FormatInt(since, 10)},
+		},
+		Limit: aws.Int32(int32(limit)),
+	}
+	if cursor != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"deviceId":  &types.AttributeValueMemberS{Value: deviceID},
+			"createdAt": &types.AttributeValueMemberN{Value: cursor},
+		}
+	}
+
+	out, err := d.ddb.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	runs := make([]model.Run, 0, len(out.Items))
+	for _, item := range out.Items {
+		runs = append(runs, runFromItem(deviceID, item))
+	}
+
+	nextCursor := ""
+	if v, ok := out.LastEvaluatedKey["createdAt"].(*types.AttributeValueMemberN); ok {
+		nextCursor = v.Value
+	}
+
+	return runs, nextCursor, nil
+}
+
+// StatsSummary sums a device's runs in [from, to], querying the range
+// directly off the runs table's createdAt sort key.
+func (d *DynamoStore) StatsSummary(ctx context.Context, deviceID string, from, to int64) (*model.Stats, error) {
+	stats := &model.Stats{DeviceID: deviceID}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(d.tables.Runs),
+		KeyConditionExpression: aws.String("deviceId = :d AND createdAt BETWEEN :from AND :to"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":d":    &types.AttributeValueMemberS{Value: deviceID},
+			":from": &types.AttributeValueMemberN{Value: strconv.FormatInt(from, 10)},
+			":to":   &types.AttributeValueMemberN{Value: strconv.FormatInt(to, 10)},
+		},
+	}
+
+	for {
+		out, err := d.ddb.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range out.Items {
+			run := runFromItem(deviceID, item)
+			stats.TotalSpent += run.AmountCharged
+			stats.NumRuns++
+		}
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+
+	return stats, nil
+}
+
+func runFromItem(deviceID string, item map[string]types.AttributeValue) model.Run {
+	run := model.Run{DeviceID: deviceID}
+
+	if v, ok := item["createdAt"].(*types.AttributeValueMemberN); ok {
+		run.CreatedAt = mustInt64(v.Value)
+	}
+	if v, ok := item["type"].(*types.AttributeValueMemberS); ok {
+		run.Type = v.Value
+	}
+	if v, ok := item["durationMinutes"].(*types.AttributeValueMemberN); ok {
+		run.DurationMinutes = int(mustInt64(v.Value))
+	}
+	if v, ok := item["amountCharged"].(*types.AttributeValueMemberN); ok {
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			run.AmountCharged = f
+		}
+	}
+	if v, ok := item["startedAt"].(*types.AttributeValueMemberN); ok {
+		run.StartedAt = mustInt64(v.Value)
+	}
+	if v, ok := item["endedAt"].(*types.AttributeValueMemberN); ok {
+		run.EndedAt = mustInt64(v.Value)
+	}
+	if v, ok := item["clientRequestId"].(*types.AttributeValueMemberS); ok {
+		run.ClientRequestID = v.Value
+	}
+
+	return run
+}
+
+func (d *DynamoStore) DeviceSecret(ctx context.Context, deviceID string) (string, bool, error) {
+	out, err := d.ddb.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.tables.Devices),
+		Key: map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: deviceID},
+		},
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if out.Item == nil {
+		return "", false, nil
+	}
+
+	v, ok := out.Item["secret"].(*types.AttributeValueMemberS)
+	if !ok {
+		return "", false, nil
+	}
+	return v.Value, true, nil
+}
+
+func (d *DynamoStore) PutDeviceSecret(ctx context.Context, deviceID, secret string) error {
+	_, err := d.ddb.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.tables.Devices),
+		Item: map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: deviceID},
+			"secret":   &types.AttributeValueMemberS{Value: secret},
+		},
+	})
+	return err
+}
+
+// CheckAndStoreNonce records nonce in the nonces table, rejecting it if it
+// has already been used. The item carries a 10 minute TTL.
+func (d *DynamoStore) CheckAndStoreNonce(ctx context.Context, nonce string) (bool, error) {
+	expiresAt := time.Now().Add(10 * time.Minute).Unix()
+
+	_, err := d.ddb.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.tables.Nonces),
+		Item: map[string]types.AttributeValue{
+			"nonce":     &types.AttributeValueMemberS{Value: nonce},
+			"expiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt, 10)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(nonce)"),
+	})
+	if err != nil {
+		var condFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &condFailed) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func mustInt64(s string) int64 {
+	var n int64
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			break
+		}
+		n = n*10 + int64(c-'0')
+	}
+	return n
+}
@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"laundry-os-api/internal/model"
+)
+
+// nonceTTL is how long a nonce is remembered for replay protection, matching
+// the window devices are expected to retry within.
+const nonceTTL = 10 * time.Minute
+
+// MemStore is an in-memory Store used by the local dev server and tests. It
+// also implements Waiter, so long-polling wakes immediately instead of
+// falling back to short-interval polling.
+type MemStore struct {
+	mu             sync.Mutex
+	latestByDevice map[string]model.Command
+	lastServedAt   map[string]int64
+	waiters        map[string]chan struct{}
+	statsByDevice  map[string]model.Stats
+	deviceSecrets  map[string]string
+
+	runMu        sync.Mutex
+	runsByDevice map[string][]model.Run
+	runKeysSeen  map[string]bool
+
+	nonceMu    sync.Mutex
+	seenNonces map[string]time.Time
+}
+
+// NewMemStore returns an empty MemStore ready to use.
+func NewMemStore() *MemStore {
+	return &MemStore{
+		latestByDevice: map[string]model.Command{},
+		lastServedAt:   map[string]int64{},
+		waiters:        map[string]chan struct{}{},
+		statsByDevice:  map[string]model.Stats{},
+		deviceSecrets:  map[string]string{},
+		runsByDevice:   map[string][]model.Run{},
+		runKeysSeen:    map[string]bool{},
+		seenNonces:     map[string]time.Time{},
+	}
+}
+
+func (m *MemStore) PutCommand(ctx context.Context, cmd model.Command) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.latestByDevice[cmd.DeviceID] = cmd
+	if ch, ok := m.waiters[cmd.DeviceID]; ok {
+		close(ch)
+		delete(m.waiters, cmd.DeviceID)
+	}
+	return nil
+}
+
+func (m *MemStore) TakeUnservedCommand(ctx context.Context, deviceID string) (*model.Command, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cmd, ok := m.latestByDevice[deviceID]
+	if !ok || m.lastServedAt[deviceID] >= cmd.CreatedAt {
+		return nil, false, nil
+	}
+
+	m.lastServedAt[deviceID] = cmd.CreatedAt
+	return &cmd, true, nil
+}
+
+// WaitForCommand implements store.Waiter by taking the unserved-command
+// check and the wake-channel registration under the same lock, so a
+// PutCommand landing between the two can't be missed.
+func (m *MemStore) WaitForCommand(ctx context.Context, deviceID string) (*model.Command, bool, <-chan struct{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cmd, ok := m.latestByDevice[deviceID]
+	if ok && m.lastServedAt[deviceID] < cmd.CreatedAt {
+		m.lastServedAt[deviceID] = cmd.CreatedAt
+		return &cmd, true, nil, nil
+	}
+
+	ch, ok := m.waiters[deviceID]
+	if !ok {
+		ch = make(chan struct{})
+		m.waiters[deviceID] = ch
+	}
+	return nil, false, ch, nil
+}
+
+func (m *MemStore) GetStats(ctx context.Context, deviceID string) (*model.Stats, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.statsByDevice[deviceID]
+	if !ok {
+		return nil, false, nil
+	}
+	return &stats, true, nil
+}
+
+func (m *MemStore) IncrementStats(ctx context.Context, deviceID string, amount float64, numRuns int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.statsByDevice[deviceID]
+	stats.DeviceID = deviceID
+	stats.TotalSpent += amount
+	stats.NumRuns += numRuns
+	m.statsByDevice[deviceID] = stats
+	return nil
+}
+
+// PutRun records run and, since there's no DynamoDB Stream to drive a
+// separate aggregator locally, folds it into the running totals inline.
+//
+// It dedups on (deviceId, createdAt) alone, matching DynamoStore's
+// attribute_not_exists(clientRequestId) condition: since every run written
+// there already carries a clientRequestId, that condition rejects any
+// second write to the same key regardless of whether clientRequestId
+// matches the first write's.
+func (m *MemStore) PutRun(ctx context.Context, run model.Run) (bool, error) {
+	key := run.DeviceID + "#" + strconv.FormatInt(run.CreatedAt, 10)
+
+	m.runMu.Lock()
+	if m.runKeysSeen[key] {
+		m.runMu.Unlock()
+		return false, nil
+	}
+	m.runKeysSeen[key] = true
+	m.runsByDevice[run.DeviceID] = append(m.runsByDevice[run.DeviceID], run)
+	m.runMu.Unlock()
+
+	return true, m.IncrementStats(ctx, run.DeviceID, run.AmountCharged, 1)
+}
+
+func (m *MemStore) ListRuns(ctx context.Context, deviceID string, since int64, limit int, cursor string) ([]model.Run, string, error) {
+	m.runMu.Lock()
+	matched := make([]model.Run, 0, len(m.runsByDevice[deviceID]))
+	for _, r := range m.runsByDevice[deviceID] {
+		if r.CreatedAt >= since {
+			matched = append(matched, r)
+		}
+	}
+	m.runMu.Unlock()
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt < matched[j].CreatedAt })
+
+	offset := 0
+	if cursor != "" {
+		if n, err := strconv.Atoi(cursor); err == nil && n > 0 {
+			offset = n
+		}
+	}
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	nextCursor := ""
+	if end < len(matched) {
+		nextCursor = strconv.Itoa(end)
+	}
+
+	return matched[offset:end], nextCursor, nil
+}
+
+func (m *MemStore) StatsSummary(ctx context.Context, deviceID string, from, to int64) (*model.Stats, error) {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+
+	stats := &model.Stats{DeviceID: deviceID}
+	for _, r := range m.runsByDevice[deviceID] {
+		if r.CreatedAt < from || r.CreatedAt > to {
+			continue
+		}
+		stats.TotalSpent += r.AmountCharged
+		stats.NumRuns++
+	}
+	return stats, nil
+}
+
+func (m *MemStore) DeviceSecret(ctx context.Context, deviceID string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	secret, ok := m.deviceSecrets[deviceID]
+	return secret, ok, nil
+}
+
+func (m *MemStore) PutDeviceSecret(ctx context.Context, deviceID, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deviceSecrets[deviceID] = secret
+	return nil
+}
+
+func (m *MemStore) CheckAndStoreNonce(ctx context.Context, nonce string) (bool, error) {
+	m.nonceMu.Lock()
+	defer m.nonceMu.Unlock()
+
+	now := time.Now()
+	for n, expiresAt := range m.seenNonces {
+		if now.After(expiresAt) {
+			delete(m.seenNonces, n)
+		}
+	}
+
+	if expiresAt, ok := m.seenNonces[nonce]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+
+	m.seenNonces[nonce] = now.Add(nonceTTL)
+	return true, nil
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"laundry-os-api/internal/model"
+)
+
+// TestMemStoreWaitForCommandAtomic guards against the check-then-register
+// race: a PutCommand landing right after WaitForCommand finds nothing
+// pending must still wake the channel WaitForCommand just registered,
+// rather than being dropped because nothing was registered yet.
+func TestMemStoreWaitForCommandAtomic(t *testing.T) {
+	m := NewMemStore()
+	ctx := context.Background()
+
+	cmd, found, wake, err := m.WaitForCommand(ctx, "dev-1")
+	if err != nil {
+		t.Fatalf("WaitForCommand: %v", err)
+	}
+	if found || cmd != nil {
+		t.Fatalf("found = %v, cmd = %+v, want nothing pending yet", found, cmd)
+	}
+
+	if err := m.PutCommand(ctx, model.Command{DeviceID: "dev-1", Type: "wash", DurationMinutes: 10, CreatedAt: 1}); err != nil {
+		t.Fatalf("PutCommand: %v", err)
+	}
+
+	select {
+	case <-wake:
+	case <-time.After(time.Second):
+		t.Fatal("wake channel never closed after PutCommand")
+	}
+
+	cmd, found, _, err = m.WaitForCommand(ctx, "dev-1")
+	if err != nil {
+		t.Fatalf("WaitForCommand: %v", err)
+	}
+	if !found || cmd == nil || cmd.Type != "wash" {
+		t.Fatalf("found = %v, cmd = %+v, want the command just put", found, cmd)
+	}
+}
+
+// TestMemStorePutRunDedupsByCreatedAt matches DynamoStore.PutRun: its
+// attribute_not_exists(clientRequestId) condition rejects any second write
+// to the same (deviceId, createdAt), even one carrying a different
+// clientRequestId, so MemStore must reject it too rather than accepting it.
+func TestMemStorePutRunDedupsByCreatedAt(t *testing.T) {
+	m := NewMemStore()
+	ctx := context.Background()
+
+	run := model.Run{DeviceID: "dev-1", CreatedAt: 1000, Type: "wash", AmountCharged: 4.5, ClientRequestID: "req-1"}
+	accepted, err := m.PutRun(ctx, run)
+	if err != nil {
+		t.Fatalf("PutRun: %v", err)
+	}
+	if !accepted {
+		t.Fatalf("accepted = false, want true for the first write")
+	}
+
+	run.ClientRequestID = "req-2"
+	accepted, err = m.PutRun(ctx, run)
+	if err != nil {
+		t.Fatalf("PutRun: %v", err)
+	}
+	if accepted {
+		t.Fatalf("accepted = true, want false for a second write at the same (deviceId, createdAt)")
+	}
+}
@@ -0,0 +1,73 @@
+// Package store defines the persistence boundary used by every handler, so
+// the same handler code can run against DynamoDB in Lambda or an in-memory
+// fake locally and in tests.
+package store
+
+import (
+	"context"
+
+	"laundry-os-api/internal/model"
+)
+
+// Store is everything a handler needs to persist or look up laundry-os-api
+// state. DynamoStore backs it with DynamoDB; MemStore fakes it in memory for
+// the local dev server and tests.
+type Store interface {
+	// PutCommand stores cmd as the device's latest command, unserved.
+	PutCommand(ctx context.Context, cmd model.Command) error
+
+	// TakeUnservedCommand returns the device's pending command, if any, and
+	// atomically marks it served. found is false when there is nothing new
+	// to deliver.
+	TakeUnservedCommand(ctx context.Context, deviceID string) (cmd *model.Command, found bool, err error)
+
+	// GetStats returns a device's running totals, as maintained by
+	// IncrementStats. found is false when the device has never run a cycle.
+	GetStats(ctx context.Context, deviceID string) (stats *model.Stats, found bool, err error)
+
+	// IncrementStats atomically folds amount and numRuns into a device's
+	// running totals, rather than overwriting them.
+	IncrementStats(ctx context.Context, deviceID string, amount float64, numRuns int64) error
+
+	// PutRun appends an immutable completed-cycle record, keyed by
+	// (deviceId, createdAt). accepted is false when a run with the same
+	// (deviceId, createdAt) was already recorded, whatever its
+	// clientRequestId, so the caller can treat this as a no-op retry instead
+	// of double-charging.
+	PutRun(ctx context.Context, run model.Run) (accepted bool, err error)
+
+	// ListRuns pages through a device's run history at/after since, oldest
+	// first. cursor is an opaque token from a previous call's nextCursor;
+	// nextCursor is empty once there's nothing more to page through.
+	ListRuns(ctx context.Context, deviceID string, since int64, limit int, cursor string) (runs []model.Run, nextCursor string, err error)
+
+	// StatsSummary sums the runs in [from, to] directly from run history,
+	// independent of the aggregates IncrementStats maintains.
+	StatsSummary(ctx context.Context, deviceID string, from, to int64) (stats *model.Stats, err error)
+
+	// DeviceSecret returns a device's HMAC secret. found is false when the
+	// device hasn't been provisioned.
+	DeviceSecret(ctx context.Context, deviceID string) (secret string, found bool, err error)
+
+	// PutDeviceSecret provisions or rotates a device's HMAC secret.
+	PutDeviceSecret(ctx context.Context, deviceID, secret string) error
+
+	// CheckAndStoreNonce records nonce for replay protection. fresh is
+	// false if the nonce has already been used.
+	CheckAndStoreNonce(ctx context.Context, nonce string) (fresh bool, err error)
+}
+
+// Waiter is implemented by stores that can wake a long-poller as soon as a
+// new command lands for a device, instead of relying on polling. Handlers
+// fall back to polling when the active Store doesn't implement it.
+type Waiter interface {
+	// WaitForCommand atomically checks for an unserved command and, if none
+	// is found, registers a wake channel, all under one lock. Doing both in
+	// one call closes the gap a separate check-then-register would leave
+	// open: a command put in between the two calls would have nothing
+	// registered to notify, so its wake would be silently lost. found is
+	// true when cmd was pending and has now been marked served, in which
+	// case wake is nil; otherwise wake closes the next time a command is
+	// put for deviceID.
+	WaitForCommand(ctx context.Context, deviceID string) (cmd *model.Command, found bool, wake <-chan struct{}, err error)
+}
@@ -1,132 +1,62 @@
 package main
 
 import (
-	"crypto/subtle"
-	"encoding/json"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"sync"
-	"time"
-)
-
-type Command struct {
-	DeviceID        string `json:"deviceId"`
-	Type            string `json:"type"` // wash | dry
-	DurationMinutes int    `json:"durationMinutes"`
-	CreatedAt       int64  `json:"createdAt"`
-	Token           string `json:"token,omitempty"` // only in request
-}
-
-type LatestResponse struct {
-	Command *Command `json:"command"`
-}
 
-var sharedSecret = os.Getenv("SHARED_SECRET")
-
-var (
-	mu             sync.Mutex
-	latestByDevice = map[string]Command{}
-	lastServedAt   = map[string]int64{}
+	"laundry-os-api/internal/api"
+	"laundry-os-api/internal/httpx"
+	"laundry-os-api/internal/store"
 )
 
-func timingSafeEq(a, b string) bool {
-	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
-}
-
-func writeJSON(w http.ResponseWriter, status int, v any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*") // fine for v1
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(v)
-}
-
-func writeErr(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, map[string]string{"error": msg})
-}
-
-func startMachine(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		writeErr(w, 405, "method not allowed")
-		return
+func flattenHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k := range h {
+		out[k] = h.Get(k)
 	}
-
-	var cmd Command
-	if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
-		writeErr(w, 400, "invalid json")
-		return
-	}
-
-	if cmd.Token == "" || !timingSafeEq(cmd.Token, sharedSecret) {
-		writeErr(w, 401, "unauthorized")
-		return
-	}
-
-	if cmd.DeviceID == "" {
-		writeErr(w, 400, "deviceId required")
-		return
-	}
-
-	if cmd.Type != "wash" && cmd.Type != "dry" {
-		writeErr(w, 400, "type must be wash or dry")
-		return
-	}
-
-	if cmd.DurationMinutes <= 0 {
-		writeErr(w, 400, "duration must be greater than 0 minutes")
-		return
-	}
-
-	cmd.CreatedAt = time.Now().UnixMilli()
-	cmd.Token = "" // don’t keep token
-
-	mu.Lock()
-	latestByDevice[cmd.DeviceID] = cmd
-	mu.Unlock()
-
-	writeJSON(w, 200, map[string]any{"ok": true, "createdAt": cmd.CreatedAt})
+	return out
 }
 
-func getLatest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeErr(w, 405, "method not allowed")
-		return
-	}
-
-	deviceID := r.URL.Query().Get("deviceId")
-	token := r.URL.Query().Get("token")
-
-	if deviceID == "" {
-		writeErr(w, 400, "deviceId required")
-		return
-	}
-
-	if token == "" || !timingSafeEq(token, sharedSecret) {
-		writeErr(w, 401, "unauthorized")
-		return
-	}
-
-	mu.Lock()
-	defer mu.Unlock()
-
-	cmd, ok := latestByDevice[deviceID]
-	if !ok {
-		writeJSON(w, 200, LatestResponse{Command: nil})
-		return
+func flattenQuery(q url.Values) map[string]string {
+	out := make(map[string]string, len(q))
+	for k := range q {
+		out[k] = q.Get(k)
 	}
+	return out
+}
 
-	if lastServedAt[deviceID] >= cmd.CreatedAt {
-		writeJSON(w, 200, LatestResponse{Command: nil})
-		return
+// serve adapts api.Server.Route to net/http, the same way cmd/api adapts it
+// to API Gateway's Lambda proxy integration.
+func serve(s *api.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			httpx.WriteJSON(w, 400, map[string]string{"error": "invalid body"})
+			return
+		}
+
+		status, respBody := s.Route(r.Context(), api.Request{
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Headers: flattenHeaders(r.Header),
+			Query:   flattenQuery(r.URL.Query()),
+			Body:    body,
+		})
+
+		httpx.WriteJSON(w, status, respBody)
 	}
-
-	lastServedAt[deviceID] = cmd.CreatedAt
-	writeJSON(w, 200, LatestResponse{Command: &cmd})
 }
 
 func main() {
-	http.HandleFunc("/start", startMachine)
-	http.HandleFunc("/command/latest", getLatest)
+	s := &api.Server{
+		Store:       store.NewMemStore(),
+		AdminSecret: os.Getenv("ADMIN_SECRET"),
+	}
+
+	http.HandleFunc("/", serve(s))
 
 	port := os.Getenv("PORT")
 	if port == "" {